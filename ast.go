@@ -0,0 +1,202 @@
+// Copyright 2020 Pavel Knoblokh. All rights reserved.
+// Use of this source code is governed by MIT License
+// that can be found in the LICENSE file.
+package exprcalc
+
+import "github.com/alecthomas/participle/lexer"
+
+// Visitor is implemented by callers that want to traverse a parsed
+// *Expression without switching on its concrete node types, modeled on
+// HIL's Accept pattern. Visit is called for every Evaluable node reachable
+// from the root; if it returns nil, Walk does not descend into that node's
+// children.
+type Visitor interface {
+	Visit(node Evaluable) Visitor
+}
+
+// Walk traverses e depth-first, calling v.Visit for every Evaluable node.
+// Nodes that only hold comparison metadata (Compare, In, Between, Like) are
+// not themselves Evaluable and are not visited, but the Evaluable operands
+// they contain (Term, Expression, ...) are.
+func Walk(e *Expression, v Visitor) {
+	walk(v, e)
+}
+
+func walk(v Visitor, node Evaluable) {
+	if v == nil || node == nil {
+		return
+	}
+
+	v = v.Visit(node)
+	if v == nil {
+		return
+	}
+
+	switch n := node.(type) {
+	case *Expression:
+		for _, or := range n.Or {
+			walk(v, or)
+		}
+		if n.Then != nil {
+			walk(v, n.Then)
+			walk(v, n.Else)
+		}
+	case *OrCondition:
+		for _, and := range n.And {
+			walk(v, and)
+		}
+	case *NotCondition:
+		walk(v, n.Operand)
+	case *ConditionOperand:
+		walk(v, n.Term)
+		walkCompare(v, n.Compare)
+	case *Term:
+		walk(v, n.Left)
+		for _, op := range n.Right {
+			walk(v, op.Multiplicative)
+		}
+	case *Multiplicative:
+		walk(v, n.Left)
+		for _, op := range n.Right {
+			walk(v, op.Unary)
+		}
+	case *Unary:
+		walk(v, n.Primary)
+	case *Primary:
+		switch {
+		case n.Value != nil:
+			walk(v, n.Value)
+		case n.Call != nil:
+			walk(v, n.Call)
+		case n.SubExpression != nil:
+			walk(v, n.SubExpression)
+		}
+	case *Call:
+		for _, a := range n.Args {
+			walk(v, a)
+		}
+	case *Value:
+		// leaf
+	}
+}
+
+// walkCompare descends into a Compare's Evaluable operands. Compare itself (and
+// its In/Between/Like variants) doesn't implement Evaluable: it needs the
+// left-hand side to evaluate, so it isn't a node Walk visits on its own.
+func walkCompare(v Visitor, c *Compare) {
+	if c == nil {
+		return
+	}
+
+	switch {
+	case c.In != nil:
+		for _, value := range c.In.Values {
+			walk(v, value)
+		}
+	case c.Between != nil:
+		walk(v, c.Between.Low)
+		walk(v, c.Between.High)
+	case c.Like != nil:
+		// pattern is a literal string, nothing to walk
+	default:
+		walk(v, c.Term)
+	}
+}
+
+// Kind is the static type of an identifier in a Validate schema.
+type Kind int
+
+const (
+	KindNumber Kind = iota
+	KindString
+	KindBool
+)
+
+func (k Kind) String() string {
+	switch k {
+	case KindNumber:
+		return "number"
+	case KindString:
+		return "string"
+	case KindBool:
+		return "bool"
+	default:
+		return "unknown"
+	}
+}
+
+// Validate statically checks e against schema, which declares the Kind of
+// every top-level identifier e may reference. It reports identifiers
+// missing from schema and relational comparisons between operands of
+// mismatched kind, turning what would otherwise be a runtime Eval error
+// into a parse-time one. Operands whose kind can't be determined
+// statically (arithmetic, function calls, dotted/indexed paths, nested
+// subexpressions) are not checked.
+func Validate(e *Expression, schema map[string]Kind) error {
+	v := &validator{schema: schema}
+	Walk(e, v)
+
+	if len(v.errs) == 0 {
+		return nil
+	}
+	return v.errs[0]
+}
+
+type validator struct {
+	schema map[string]Kind
+	errs   []error
+}
+
+func (val *validator) Visit(node Evaluable) Visitor {
+	switch n := node.(type) {
+	case *Primary:
+		if n.Identifier != nil && len(n.Identifier.Segments) == 1 {
+			name := n.Identifier.Segments[0].Name
+			if _, ok := val.schema[name]; !ok {
+				val.errs = append(val.errs, lexer.Errorf(n.Pos, "unknown identifier %s", name))
+			}
+		}
+	case *ConditionOperand:
+		if n.Compare != nil && n.Compare.Term != nil {
+			lhsKind, lhsOk := staticKind(n.Term, val.schema)
+			rhsKind, rhsOk := staticKind(n.Compare.Term, val.schema)
+			if lhsOk && rhsOk && lhsKind != rhsKind {
+				val.errs = append(val.errs, lexer.Errorf(n.Pos,
+					"cannot compare %s with %s", lhsKind, rhsKind))
+			}
+		}
+	}
+	return val
+}
+
+// staticKind returns the Kind of t if it reduces to a single literal Value
+// or a single top-level identifier declared in schema, and false otherwise.
+func staticKind(t *Term, schema map[string]Kind) (Kind, bool) {
+	if len(t.Right) != 0 {
+		return 0, false
+	}
+	m := t.Left
+	if len(m.Right) != 0 {
+		return 0, false
+	}
+	return staticKindOfPrimary(m.Left.Primary, schema)
+}
+
+func staticKindOfPrimary(p *Primary, schema map[string]Kind) (Kind, bool) {
+	switch {
+	case p.Value != nil:
+		switch {
+		case p.Value.Number != nil:
+			return KindNumber, true
+		case p.Value.String != nil:
+			return KindString, true
+		case p.Value.Boolean != nil:
+			return KindBool, true
+		}
+	case p.Identifier != nil && len(p.Identifier.Segments) == 1 &&
+		len(p.Identifier.Segments[0].Indices) == 0:
+		kind, ok := schema[p.Identifier.Segments[0].Name]
+		return kind, ok
+	}
+	return 0, false
+}
@@ -0,0 +1,80 @@
+// Copyright 2020 Pavel Knoblokh. All rights reserved.
+// Use of this source code is governed by MIT License
+// that can be found in the LICENSE file.
+package exprcalc
+
+import "testing"
+
+type countingVisitor struct {
+	count int
+}
+
+func (c *countingVisitor) Visit(node Evaluable) Visitor {
+	c.count++
+	return c
+}
+
+func TestWalk(t *testing.T) {
+	e, err := Parse(`age >= 18 AND (gender == "male" OR married) AND abs(-1) == 1`)
+	if err != nil {
+		t.Fatalf("Parse error '%v'", err)
+	}
+
+	v := &countingVisitor{}
+	Walk(e, v)
+
+	if v.count == 0 {
+		t.Error("Walk must visit at least the root node")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	schema := map[string]Kind{
+		"age":    KindNumber,
+		"gender": KindString,
+	}
+
+	tests := []struct {
+		name    string
+		expr    string
+		wantErr bool
+	}{
+		{
+			"Valid identifiers and comparisons",
+			`age >= 18 AND gender == "male"`,
+			false,
+		},
+		{
+			"Unknown identifier",
+			`height > 18`,
+			true,
+		},
+		{
+			"Mismatched comparison kinds",
+			`age == "18"`,
+			true,
+		},
+		{
+			"Unchecked dotted path is not an error",
+			`gender.nickname == "x"`,
+			false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e, err := Parse(tt.expr)
+			if err != nil {
+				t.Fatalf("Parse error '%v'", err)
+			}
+
+			err = Validate(e, schema)
+			if tt.wantErr && err == nil {
+				t.Error("Validate must fail")
+			}
+			if !tt.wantErr && err != nil {
+				t.Errorf("Validate error '%v'", err)
+			}
+		})
+	}
+}
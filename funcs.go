@@ -0,0 +1,148 @@
+// Copyright 2020 Pavel Knoblokh. All rights reserved.
+// Use of this source code is governed by MIT License
+// that can be found in the LICENSE file.
+package exprcalc
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Func is a user-defined function pluggable into a FuncRegistry and callable
+// from within an expression via a Call term, e.g. `len(name) > 0`. Arguments
+// and the return value use the same representation as Gettable.GetByName:
+// a float64, string or bool.
+type Func func(args ...interface{}) (interface{}, error)
+
+// FuncRegistry maps function names to their implementations. It is safe for
+// concurrent use, since DefaultFuncRegistry is an exported package-level
+// singleton that callers may register into from one goroutine while
+// evaluating expressions against it from another.
+type FuncRegistry struct {
+	mu    sync.RWMutex
+	funcs map[string]Func
+}
+
+// NewFuncRegistry returns a registry pre-populated with the built-in
+// string and numeric helpers.
+func NewFuncRegistry() *FuncRegistry {
+	r := &FuncRegistry{funcs: make(map[string]Func)}
+	registerBuiltins(r)
+	return r
+}
+
+// RegisterFunc registers fn under name, overwriting any previous
+// registration under that name, including a built-in.
+func (r *FuncRegistry) RegisterFunc(name string, fn Func) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.funcs[name] = fn
+}
+
+func (r *FuncRegistry) call(name string, args []interface{}) (interface{}, error) {
+	r.mu.RLock()
+	fn, ok := r.funcs[name]
+	r.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("undefined function %s", name)
+	}
+	return fn(args...)
+}
+
+// DefaultFuncRegistry is the registry used by Eval and EvalParsed.
+var DefaultFuncRegistry = NewFuncRegistry()
+
+func registerBuiltins(r *FuncRegistry) {
+	r.RegisterFunc("len", funcLen)
+	r.RegisterFunc("lower", funcLower)
+	r.RegisterFunc("contains", funcContains)
+	r.RegisterFunc("now", funcNow)
+	r.RegisterFunc("abs", funcAbs)
+	r.RegisterFunc("matches", funcMatches)
+}
+
+func funcLen(args ...interface{}) (interface{}, error) {
+	if len(args) != 1 {
+		return nil, fmt.Errorf("len() takes exactly 1 argument, got %d", len(args))
+	}
+	s, err := stringArg("len", args, 0)
+	if err != nil {
+		return nil, err
+	}
+	return float64(len(s)), nil
+}
+
+func funcLower(args ...interface{}) (interface{}, error) {
+	if len(args) != 1 {
+		return nil, fmt.Errorf("lower() takes exactly 1 argument, got %d", len(args))
+	}
+	s, err := stringArg("lower", args, 0)
+	if err != nil {
+		return nil, err
+	}
+	return strings.ToLower(s), nil
+}
+
+func funcContains(args ...interface{}) (interface{}, error) {
+	if len(args) != 2 {
+		return nil, fmt.Errorf("contains() takes exactly 2 arguments, got %d", len(args))
+	}
+	s, err := stringArg("contains", args, 0)
+	if err != nil {
+		return nil, err
+	}
+	sub, err := stringArg("contains", args, 1)
+	if err != nil {
+		return nil, err
+	}
+	return strings.Contains(s, sub), nil
+}
+
+func funcNow(args ...interface{}) (interface{}, error) {
+	if len(args) != 0 {
+		return nil, fmt.Errorf("now() takes no arguments, got %d", len(args))
+	}
+	return float64(time.Now().Unix()), nil
+}
+
+func funcAbs(args ...interface{}) (interface{}, error) {
+	if len(args) != 1 {
+		return nil, fmt.Errorf("abs() takes exactly 1 argument, got %d", len(args))
+	}
+	n, ok := args[0].(float64)
+	if !ok {
+		return nil, fmt.Errorf("abs() argument must be a number")
+	}
+	return math.Abs(n), nil
+}
+
+func funcMatches(args ...interface{}) (interface{}, error) {
+	if len(args) != 2 {
+		return nil, fmt.Errorf("matches() takes exactly 2 arguments, got %d", len(args))
+	}
+	s, err := stringArg("matches", args, 0)
+	if err != nil {
+		return nil, err
+	}
+	pattern, err := stringArg("matches", args, 1)
+	if err != nil {
+		return nil, err
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("matches() invalid pattern: %v", err)
+	}
+	return re.MatchString(s), nil
+}
+
+func stringArg(name string, args []interface{}, i int) (string, error) {
+	s, ok := args[i].(string)
+	if !ok {
+		return "", fmt.Errorf("%s() argument %d must be a string", name, i+1)
+	}
+	return s, nil
+}
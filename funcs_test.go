@@ -0,0 +1,153 @@
+// Copyright 2020 Pavel Knoblokh. All rights reserved.
+// Use of this source code is governed by MIT License
+// that can be found in the LICENSE file.
+package exprcalc
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestCall(t *testing.T) {
+	tests := []struct {
+		name string
+		expr string
+		want interface{}
+	}{
+		{
+			"len of string literal",
+			`len("asdf")`,
+			4.0,
+		},
+		{
+			"lower of string literal",
+			`lower("ASDF")`,
+			"asdf",
+		},
+		{
+			"contains true",
+			`contains("asdf", "sd")`,
+			true,
+		},
+		{
+			"contains false",
+			`contains("asdf", "xy")`,
+			false,
+		},
+		{
+			"abs of negative number",
+			`abs(-5)`,
+			5.0,
+		},
+		{
+			"matches true",
+			`matches("asdf", "^a.*f$")`,
+			true,
+		},
+		{
+			"matches false",
+			`matches("asdf", "^b")`,
+			false,
+		},
+		{
+			"call result usable in comparison",
+			`len("asdf") == 4`,
+			true,
+		},
+		{
+			"call with identifier argument",
+			`len(gender) > 0`,
+			true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			value, err := Eval(tt.expr, &testPerson{"male", 22, true})
+
+			if err != nil {
+				t.Errorf("Eval error '%v'", err)
+			}
+
+			if value != tt.want {
+				t.Errorf("Value error: want '%v', got '%v'", tt.want, value)
+			}
+		})
+	}
+}
+
+func TestCallErr(t *testing.T) {
+	tests := []struct {
+		name string
+		expr string
+	}{
+		{
+			"Undefined function",
+			`nope("asdf")`,
+		},
+		{
+			"Wrong argument count",
+			`len("a", "b")`,
+		},
+		{
+			"Wrong argument type",
+			`len(123)`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Eval(tt.expr, nil)
+
+			if err == nil {
+				t.Error("Must fail")
+			}
+		})
+	}
+}
+
+func TestRegisterFunc(t *testing.T) {
+	funcs := NewFuncRegistry()
+	funcs.RegisterFunc("double", func(args ...interface{}) (interface{}, error) {
+		n, ok := args[0].(float64)
+		if !ok {
+			return nil, nil
+		}
+		return n * 2, nil
+	})
+
+	value, err := EvalWithFuncs(`double(21)`, nil, funcs)
+	if err != nil {
+		t.Errorf("Eval error '%v'", err)
+	}
+	if value != 42.0 {
+		t.Errorf("Value error: want '42', got '%v'", value)
+	}
+}
+
+// TestFuncRegistryConcurrentAccess guards against concurrent RegisterFunc
+// and call racing on the underlying map; the race is reported when the
+// tests are run with -race.
+func TestFuncRegistryConcurrentAccess(t *testing.T) {
+	funcs := NewFuncRegistry()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			funcs.RegisterFunc("double", func(args ...interface{}) (interface{}, error) {
+				n, ok := args[0].(float64)
+				if !ok {
+					return nil, nil
+				}
+				return n * 2, nil
+			})
+		}()
+		go func() {
+			defer wg.Done()
+			EvalWithFuncs(`len("asdf")`, nil, funcs)
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,138 @@
+// Copyright 2020 Pavel Knoblokh. All rights reserved.
+// Use of this source code is governed by MIT License
+// that can be found in the LICENSE file.
+package exprcalc
+
+import (
+	"fmt"
+	"testing"
+)
+
+type testProfile struct {
+	City string
+}
+
+type testRecord struct{}
+
+func (r *testRecord) GetByName(name string) (interface{}, error) {
+	switch name {
+	case "profile":
+		return testProfile{City: "Springfield"}, nil
+	case "attrs":
+		return map[string]interface{}{"role": "admin"}, nil
+	case "items":
+		return []interface{}{"a", "b", "c"}, nil
+	case "nested":
+		return &testRecord{}, nil
+	}
+	return nil, fmt.Errorf("invalid identifier")
+}
+
+type testIndexable struct{}
+
+func (i *testIndexable) GetByName(name string) (interface{}, error) {
+	if name == "tags" {
+		return &testIndexable{}, nil
+	}
+	return nil, fmt.Errorf("invalid identifier")
+}
+
+func (i *testIndexable) GetByIndex(index int) (interface{}, error) {
+	items := []string{"x", "y", "z"}
+	if index < 0 || index >= len(items) {
+		return nil, fmt.Errorf("index %d out of range", index)
+	}
+	return items[index], nil
+}
+
+func (i *testIndexable) GetByKey(key string) (interface{}, error) {
+	return nil, fmt.Errorf("no such key %s", key)
+}
+
+func TestIdentifierPath(t *testing.T) {
+	tests := []struct {
+		name string
+		expr string
+		obj  Gettable
+		want interface{}
+	}{
+		{
+			"Struct field access",
+			`profile.City == "Springfield"`,
+			&testRecord{},
+			true,
+		},
+		{
+			"Map key access",
+			`attrs["role"] == "admin"`,
+			&testRecord{},
+			true,
+		},
+		{
+			"Slice index access",
+			`items[1] == "b"`,
+			&testRecord{},
+			true,
+		},
+		{
+			"Nested Gettable",
+			`nested.profile.City == "Springfield"`,
+			&testRecord{},
+			true,
+		},
+		{
+			"Indexable bracket access",
+			`tags[0] == "x"`,
+			&testIndexable{},
+			true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			value, err := Eval(tt.expr, tt.obj)
+
+			if err != nil {
+				t.Errorf("Eval error '%v'", err)
+			}
+
+			if value != tt.want {
+				t.Errorf("Value error: want '%v', got '%v'", tt.want, value)
+			}
+		})
+	}
+}
+
+func TestIdentifierPathErr(t *testing.T) {
+	tests := []struct {
+		name string
+		expr string
+		obj  Gettable
+	}{
+		{
+			"Index out of range",
+			`items[5]`,
+			&testRecord{},
+		},
+		{
+			"No such struct field",
+			`profile.Country`,
+			&testRecord{},
+		},
+		{
+			"No such map key",
+			`attrs["missing"]`,
+			&testRecord{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Eval(tt.expr, tt.obj)
+
+			if err == nil {
+				t.Error("Must fail")
+			}
+		})
+	}
+}
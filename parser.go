@@ -6,7 +6,10 @@ package exprcalc
 
 import (
 	"fmt"
+	"math"
+	"regexp"
 	"strings"
+	"sync"
 
 	"github.com/alecthomas/participle"
 	"github.com/alecthomas/participle/lexer"
@@ -25,6 +28,15 @@ type (
 		GetByName(string) (interface{}, error)
 	}
 
+	// Indexable is implemented by objects that support bracket indexing,
+	// e.g. `items[0]` or `attrs["role"]`. Objects that don't implement it
+	// fall back to the reflection-based defaults in resolve(), which cover
+	// plain []interface{} and map[string]interface{} values.
+	Indexable interface {
+		GetByIndex(int) (interface{}, error)
+		GetByKey(string) (interface{}, error)
+	}
+
 	Boolean bool
 )
 
@@ -33,16 +45,31 @@ func (b *Boolean) Capture(values []string) error {
 	return nil
 }
 
+// Expression is an OR-chain optionally followed by a ternary conditional,
+// e.g. `age >= 18 ? "adult" : "minor"`. The condition is itself an OR-chain,
+// but the two branches may recurse into full expressions (including further
+// ternaries).
 type Expression struct {
 	Pos lexer.Position
 
-	Or []*OrCondition `@@ { "OR" @@ }`
+	Or   []*OrCondition `@@ { "OR" @@ }`
+	Then *Expression    `[ "?" @@`
+	Else *Expression    `  ":" @@ ]`
 }
 
 type OrCondition struct {
 	Pos lexer.Position
 
-	And []*ConditionOperand `@@ { "AND" @@ }`
+	And []*NotCondition `@@ { "AND" @@ }`
+}
+
+// NotCondition is a ConditionOperand with an optional unary NOT/! prefix,
+// e.g. `NOT married` or `!(age > 18 AND gender == "male")`.
+type NotCondition struct {
+	Pos lexer.Position
+
+	Not     bool              `[ @( "NOT" | "!" ) ]`
+	Operand *ConditionOperand `@@`
 }
 
 type ConditionOperand struct {
@@ -52,21 +79,152 @@ type ConditionOperand struct {
 	Compare *Compare `[ @@ ]`
 }
 
+// Compare is either a relational operator applied to a Term, or one of the
+// IN/BETWEEN/LIKE membership and pattern-matching forms.
 type Compare struct {
 	Pos lexer.Position
 
-	Operator string `@( "<=" | ">=" | "==" | "<" | ">" | "!=" )`
-	Term     *Term  `( @@ )`
+	Operator string   `(   @( "<=" | ">=" | "==" | "<" | ">" | "!=" )`
+	Term     *Term    `    @@`
+	In       *In      `  | @@`
+	Between  *Between `  | @@`
+	Like     *Like    `  | @@ )`
+}
+
+// In is `x IN (a, b, c)`, matching if the left-hand side equals any of the
+// listed values.
+type In struct {
+	Pos lexer.Position
+
+	Values []*Expression `"IN" "(" @@ { "," @@ } ")"`
+}
+
+// Between is `x BETWEEN low AND high`, matching if the left-hand side is
+// within the inclusive range.
+type Between struct {
+	Pos lexer.Position
+
+	Low  *Term `"BETWEEN" @@`
+	High *Term `"AND" @@`
 }
 
+// Like is `x LIKE "pattern"`, a SQL-style pattern match where `%` matches
+// any run of characters and `_` matches a single character. The pattern is
+// compiled to an anchored regexp the first time it is evaluated and cached
+// so repeated EvalParsed calls don't recompile it. reOnce guards the cache
+// so concurrent Eval calls on a shared parsed *Expression don't race.
+type Like struct {
+	Pos lexer.Position
+
+	Pattern string `"LIKE" @String`
+
+	reOnce sync.Once
+	re     *regexp.Regexp
+	reErr  error
+}
+
+// Term is an additive chain: Multiplicative { ("+" | "-") Multiplicative }.
 type Term struct {
 	Pos lexer.Position
 
+	Left  *Multiplicative     `@@`
+	Right []*OpMultiplicative `{ @@ }`
+}
+
+type OpMultiplicative struct {
+	Pos lexer.Position
+
+	Operator       string          `@( "+" | "-" )`
+	Multiplicative *Multiplicative `@@`
+}
+
+// Multiplicative is a multiplicative chain: Unary { ("*" | "/" | "%") Unary }.
+type Multiplicative struct {
+	Pos lexer.Position
+
+	Left  *Unary     `@@`
+	Right []*OpUnary `{ @@ }`
+}
+
+type OpUnary struct {
+	Pos lexer.Position
+
+	Operator string `@( "*" | "/" | "%" )`
+	Unary    *Unary `@@`
+}
+
+// Unary is an optionally-signed Primary, e.g. -age or +3.
+type Unary struct {
+	Pos lexer.Position
+
+	Operator *string  `[ @( "-" | "+" ) ]`
+	Primary  *Primary `@@`
+}
+
+type Primary struct {
+	Pos lexer.Position
+
 	Value         *Value      `@@`
-	Identifier    *string     `| @Ident`
+	Call          *Call       `| @@`
+	Identifier    *Identifier `| @@`
 	SubExpression *Expression `| "(" @@ ")"`
 }
 
+// Call is a user-defined function invocation, e.g. `lower(name)` or
+// `contains(name, "a")`. The callee is resolved against the FuncRegistry
+// in the evaluation Context.
+type Call struct {
+	Pos lexer.Position
+
+	Name string        `@Ident "("`
+	Args []*Expression `[ @@ { "," @@ } ] ")"`
+}
+
+// Identifier is a dotted, optionally-indexed path into the evaluation
+// Context's object, e.g. `user.address.city`, `items[0]` or
+// `attrs["role"]`. Each dot-separated part is a Segment; bracket indices
+// bind to the Segment they directly follow.
+type Identifier struct {
+	Pos lexer.Position
+
+	Segments []*Segment `@@ { "." @@ }`
+}
+
+type Segment struct {
+	Pos lexer.Position
+
+	Name    string   `@Ident`
+	Indices []*Index `{ @@ }`
+}
+
+// Index is a single bracketed subscript, either a numeric index into a
+// sequence (`[0]`) or a string key into a map (`["role"]`).
+type Index struct {
+	Pos lexer.Position
+
+	Int *float64 `"[" (  @Number`
+	Key *string  `      | @String ) "]"`
+}
+
+func (i *Identifier) String() string {
+	var b strings.Builder
+	for si, seg := range i.Segments {
+		if si > 0 {
+			b.WriteString(".")
+		}
+		b.WriteString(seg.Name)
+		for _, idx := range seg.Indices {
+			switch {
+			case idx.Int != nil:
+				fmt.Fprintf(&b, "[%d]", int(*idx.Int))
+			case idx.Key != nil:
+				fmt.Fprintf(&b, "[%q]", *idx.Key)
+			}
+		}
+	}
+	return b.String()
+}
+
 type Value struct {
 	Pos lexer.Position
 
@@ -77,10 +235,52 @@ type Value struct {
 
 type Context struct {
 	Object Gettable
+	Funcs  *FuncRegistry
+}
+
+// resolve walks path's segments and indices against ctx.Object, calling
+// GetByName for each named segment and GetByIndex/GetByKey for each
+// bracketed index. Values that don't implement Gettable/Indexable fall
+// back to the reflection-based defaults in reflection.go.
+func (ctx *Context) resolve(path *Identifier) (interface{}, error) {
+	var current interface{} = ctx.Object
+
+	for _, seg := range path.Segments {
+		value, err := getByName(current, seg.Name)
+		if err != nil {
+			return nil, err
+		}
+		current = value
+
+		for _, idx := range seg.Indices {
+			switch {
+			case idx.Int != nil:
+				value, err := getByIndex(current, int(*idx.Int))
+				if err != nil {
+					return nil, err
+				}
+				current = value
+			case idx.Key != nil:
+				value, err := getByKey(current, *idx.Key)
+				if err != nil {
+					return nil, err
+				}
+				current = value
+			}
+		}
+	}
+
+	return current, nil
 }
 
 // Returns a float64, string or bool
 func Eval(expr string, obj Gettable) (interface{}, error) {
+	return EvalWithFuncs(expr, obj, DefaultFuncRegistry)
+}
+
+// EvalWithFuncs is like Eval but resolves Call terms against funcs instead
+// of DefaultFuncRegistry.
+func EvalWithFuncs(expr string, obj Gettable, funcs *FuncRegistry) (interface{}, error) {
 	if len(expr) == 0 {
 		return nil, nil
 	}
@@ -94,7 +294,7 @@ func Eval(expr string, obj Gettable) (interface{}, error) {
 		repr.Println(e, repr.Indent("      "), repr.OmitEmpty(true))
 	}
 
-	return EvalParsed(e, obj)
+	return EvalParsedWithFuncs(e, obj, funcs)
 }
 
 func Parse(expr string) (*Expression, error) {
@@ -107,7 +307,13 @@ func Parse(expr string) (*Expression, error) {
 }
 
 func EvalParsed(expr *Expression, obj Gettable) (interface{}, error) {
-	ctx := &Context{obj}
+	return EvalParsedWithFuncs(expr, obj, DefaultFuncRegistry)
+}
+
+// EvalParsedWithFuncs is like EvalParsed but resolves Call terms against
+// funcs instead of DefaultFuncRegistry.
+func EvalParsedWithFuncs(expr *Expression, obj Gettable, funcs *FuncRegistry) (interface{}, error) {
+	ctx := &Context{Object: obj, Funcs: funcs}
 
 	value, err := expr.Eval(ctx)
 	if err != nil {
@@ -118,6 +324,27 @@ func EvalParsed(expr *Expression, obj Gettable) (interface{}, error) {
 }
 
 func (e *Expression) Eval(ctx *Context) (interface{}, error) {
+	cond, err := e.evalOr(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if e.Then == nil {
+		return cond, nil
+	}
+
+	condBool, ok := cond.(Boolean)
+	if !ok {
+		return nil, lexer.Errorf(e.Pos, "ternary condition must be boolean")
+	}
+
+	if condBool {
+		return e.Then.Eval(ctx)
+	}
+	return e.Else.Eval(ctx)
+}
+
+func (e *Expression) evalOr(ctx *Context) (interface{}, error) {
 	if len(e.Or) == 0 {
 		return nil, nil
 	}
@@ -185,6 +412,24 @@ func (o *OrCondition) Eval(ctx *Context) (interface{}, error) {
 	return lhs, nil
 }
 
+func (n *NotCondition) Eval(ctx *Context) (interface{}, error) {
+	value, err := n.Operand.Eval(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if !n.Not {
+		return value, nil
+	}
+
+	b, ok := value.(Boolean)
+	if !ok {
+		return nil, lexer.Errorf(n.Pos, "operand of NOT must be boolean")
+	}
+
+	return Boolean(!b), nil
+}
+
 func (c *ConditionOperand) Eval(ctx *Context) (interface{}, error) {
 	lhs, err := c.Term.Eval(ctx)
 	if err != nil {
@@ -204,6 +449,15 @@ func (c *ConditionOperand) Eval(ctx *Context) (interface{}, error) {
 }
 
 func (c *Compare) Eval(ctx *Context, lhs interface{}) (interface{}, error) {
+	switch {
+	case c.In != nil:
+		return c.In.Eval(ctx, lhs)
+	case c.Between != nil:
+		return c.Between.Eval(ctx, lhs)
+	case c.Like != nil:
+		return c.Like.Eval(ctx, lhs)
+	}
+
 	rhs, err := c.Term.Eval(ctx)
 	if err != nil {
 		return nil, err
@@ -272,22 +526,139 @@ func (c *Compare) Eval(ctx *Context, lhs interface{}) (interface{}, error) {
 }
 
 func (t *Term) Eval(ctx *Context) (interface{}, error) {
+	lhs, err := t.Left.Eval(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, op := range t.Right {
+		rhs, err := op.Multiplicative.Eval(ctx)
+		if err != nil {
+			return nil, err
+		}
+		lhs, err = evalAdditive(t.Pos, lhs, op.Operator, rhs)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return lhs, nil
+}
+
+func evalAdditive(pos lexer.Position, lhs interface{}, operator string, rhs interface{}) (interface{}, error) {
+	switch lhs := lhs.(type) {
+	case float64:
+		rhs, ok := rhs.(float64)
+		if !ok {
+			return nil, lexer.Errorf(pos, "rhs of %s must be a number", operator)
+		}
+		switch operator {
+		case "+":
+			return lhs + rhs, nil
+		case "-":
+			return lhs - rhs, nil
+		}
+	case string:
+		if operator != "+" {
+			return nil, lexer.Errorf(pos, "unsupported string operator %s", operator)
+		}
+		rhs, ok := rhs.(string)
+		if !ok {
+			return nil, lexer.Errorf(pos, "rhs of %s must be a string", operator)
+		}
+		return lhs + rhs, nil
+	}
+	return nil, lexer.Errorf(pos, "lhs of %s must be a number or string", operator)
+}
+
+func (m *Multiplicative) Eval(ctx *Context) (interface{}, error) {
+	lhs, err := m.Left.Eval(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, op := range m.Right {
+		rhs, err := op.Unary.Eval(ctx)
+		if err != nil {
+			return nil, err
+		}
+		lhs, err = evalMultiplicative(m.Pos, lhs, op.Operator, rhs)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return lhs, nil
+}
+
+func evalMultiplicative(pos lexer.Position, lhs interface{}, operator string, rhs interface{}) (interface{}, error) {
+	lhsNum, ok := lhs.(float64)
+	if !ok {
+		return nil, lexer.Errorf(pos, "lhs of %s must be a number", operator)
+	}
+	rhsNum, ok := rhs.(float64)
+	if !ok {
+		return nil, lexer.Errorf(pos, "rhs of %s must be a number", operator)
+	}
+
+	switch operator {
+	case "*":
+		return lhsNum * rhsNum, nil
+	case "/":
+		if rhsNum == 0 {
+			return nil, lexer.Errorf(pos, "division by zero")
+		}
+		return lhsNum / rhsNum, nil
+	case "%":
+		if rhsNum == 0 {
+			return nil, lexer.Errorf(pos, "division by zero")
+		}
+		return math.Mod(lhsNum, rhsNum), nil
+	default:
+		return nil, lexer.Errorf(pos, "unsupported arithmetic operator %s", operator)
+	}
+}
+
+func (u *Unary) Eval(ctx *Context) (interface{}, error) {
+	value, err := u.Primary.Eval(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if u.Operator == nil {
+		return value, nil
+	}
+
+	num, ok := value.(float64)
+	if !ok {
+		return nil, lexer.Errorf(u.Pos, "unary %s requires a number", *u.Operator)
+	}
+
+	if *u.Operator == "-" {
+		return -num, nil
+	}
+	return num, nil
+}
+
+func (p *Primary) Eval(ctx *Context) (interface{}, error) {
 	switch {
-	case t.Value != nil:
-		return t.Value.Eval(ctx)
-	case t.Identifier != nil:
+	case p.Value != nil:
+		return p.Value.Eval(ctx)
+	case p.Call != nil:
+		return p.Call.Eval(ctx)
+	case p.Identifier != nil:
 		if ctx.Object == nil {
-			return nil, lexer.Errorf(t.Pos, "Identifier %v on nil object", t.Identifier)
+			return nil, lexer.Errorf(p.Pos, "Identifier %v on nil object", p.Identifier)
 		}
-		value, err := ctx.Object.GetByName(*t.Identifier)
+		value, err := ctx.resolve(p.Identifier)
 		if err != nil {
-			return nil, lexer.Errorf(t.Pos, "%v", err)
+			return nil, lexer.Errorf(p.Pos, "%v", err)
 		}
 		return castToInternal(value), nil
-	case t.SubExpression != nil:
-		return t.SubExpression.Eval(ctx)
+	case p.SubExpression != nil:
+		return p.SubExpression.Eval(ctx)
 	}
-	panic("unsupported term type" + repr.String(t))
+	panic("unsupported primary type" + repr.String(p))
 }
 
 func (v *Value) Eval(ctx *Context) (interface{}, error) {
@@ -302,6 +673,119 @@ func (v *Value) Eval(ctx *Context) (interface{}, error) {
 	panic("unsupported value type" + repr.String(v))
 }
 
+func (in *In) Eval(ctx *Context, lhs interface{}) (interface{}, error) {
+	for _, v := range in.Values {
+		rhs, err := v.Eval(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if valuesEqual(lhs, rhs) {
+			return Boolean(true), nil
+		}
+	}
+	return Boolean(false), nil
+}
+
+func (b *Between) Eval(ctx *Context, lhs interface{}) (interface{}, error) {
+	low, err := b.Low.Eval(ctx)
+	if err != nil {
+		return nil, err
+	}
+	high, err := b.High.Eval(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	switch lhs := lhs.(type) {
+	case float64:
+		lowNum, ok1 := low.(float64)
+		highNum, ok2 := high.(float64)
+		if !ok1 || !ok2 {
+			return nil, lexer.Errorf(b.Pos, "bounds of BETWEEN must be numbers")
+		}
+		return Boolean(lhs >= lowNum && lhs <= highNum), nil
+	case string:
+		lowStr, ok1 := low.(string)
+		highStr, ok2 := high.(string)
+		if !ok1 || !ok2 {
+			return nil, lexer.Errorf(b.Pos, "bounds of BETWEEN must be strings")
+		}
+		return Boolean(lhs >= lowStr && lhs <= highStr), nil
+	default:
+		return nil, lexer.Errorf(b.Pos, "lhs of BETWEEN must be a number or string")
+	}
+}
+
+func (l *Like) Eval(ctx *Context, lhs interface{}) (interface{}, error) {
+	s, ok := lhs.(string)
+	if !ok {
+		return nil, lexer.Errorf(l.Pos, "lhs of LIKE must be a string")
+	}
+
+	l.reOnce.Do(func() {
+		l.re, l.reErr = compileLikePattern(l.Pattern)
+	})
+	if l.reErr != nil {
+		return nil, lexer.Errorf(l.Pos, "invalid LIKE pattern: %v", l.reErr)
+	}
+
+	return Boolean(l.re.MatchString(s)), nil
+}
+
+func compileLikePattern(pattern string) (*regexp.Regexp, error) {
+	var b strings.Builder
+	b.WriteString("(?s)^")
+	for _, r := range pattern {
+		switch r {
+		case '%':
+			b.WriteString(".*")
+		case '_':
+			b.WriteString(".")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	b.WriteString("$")
+	return regexp.Compile(b.String())
+}
+
+func valuesEqual(a, b interface{}) bool {
+	switch a := a.(type) {
+	case float64:
+		b, ok := b.(float64)
+		return ok && a == b
+	case string:
+		b, ok := b.(string)
+		return ok && a == b
+	case Boolean:
+		b, ok := b.(Boolean)
+		return ok && a == b
+	}
+	return false
+}
+
+func (c *Call) Eval(ctx *Context) (interface{}, error) {
+	if ctx.Funcs == nil {
+		return nil, lexer.Errorf(c.Pos, "function %s is not available", c.Name)
+	}
+
+	args := make([]interface{}, len(c.Args))
+	for i, a := range c.Args {
+		value, err := a.Eval(ctx)
+		if err != nil {
+			return nil, err
+		}
+		args[i] = castToExternal(value)
+	}
+
+	result, err := ctx.Funcs.call(c.Name, args)
+	if err != nil {
+		return nil, lexer.Errorf(c.Pos, "%v", err)
+	}
+
+	return castToInternal(result), nil
+}
+
 func castToInternal(value interface{}) interface{} {
 	switch value := value.(type) {
 	case bool:
@@ -358,17 +842,20 @@ func evaluateBooleans(ctx *Context, lhs interface{}, rhsExpr Evaluable) (Boolean
 
 var (
 	myLexer = lexer.Must(lexer.Regexp(`(\s+)` +
-		`|(?P<LogicOp>(?i)AND|OR)` +
+		`|(?P<LogicOp>(?i)\b(?:AND|OR|NOT)\b)` +
 		`|(?P<Boolean>(?i)true|false)` +
+		`|(?P<Keyword>(?i)\b(?:IN|BETWEEN|LIKE)\b)` +
 		`|(?P<Ident>[a-zA-Z_][a-zA-Z0-9_]*)` +
-		`|(?P<Number>[-+]?\d*\.?\d+([eE][-+]?\d+)?)` +
+		`|(?P<Number>\d*\.?\d+([eE][-+]?\d+)?)` +
 		`|(?P<String>'[^']*'|"[^"]*")` +
-		`|(?P<CompareOp>!=|<=|>=|==|[()<>])`,
+		`|(?P<CompareOp>!=|!|<=|>=|==|[()<>])` +
+		`|(?P<ArithOp>[-+*/%])` +
+		`|(?P<Punct>[?:,.\[\]])`,
 	))
 	Parser = participle.MustBuild(
 		&Expression{},
 		participle.Lexer(myLexer),
 		participle.Unquote("String"),
-		participle.CaseInsensitive("LogicOp", "Boolean"),
+		participle.CaseInsensitive("LogicOp", "Boolean", "Keyword"),
 	)
 )
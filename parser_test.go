@@ -62,6 +62,41 @@ func TestParserErr(t *testing.T) {
 			`height == 182`,
 			&testPerson{"male", 22, true},
 		},
+		{
+			"Number minus string",
+			`1 - "a"`,
+			nil,
+		},
+		{
+			"String times string",
+			`"a" * "b"`,
+			nil,
+		},
+		{
+			"Division by zero",
+			`1 / 0`,
+			nil,
+		},
+		{
+			"Ternary condition must be boolean",
+			`1 ? "a" : "b"`,
+			nil,
+		},
+		{
+			"BETWEEN bounds must be numbers",
+			`5 BETWEEN "a" AND 10`,
+			nil,
+		},
+		{
+			"LIKE lhs must be a string",
+			`5 LIKE "5"`,
+			nil,
+		},
+		{
+			"NOT operand must be boolean",
+			`NOT 5`,
+			nil,
+		},
 	}
 
 	for _, tt := range tests {
@@ -360,6 +395,165 @@ func TestParser(t *testing.T) {
 			`false AND 123`,
 			false,
 		},
+		// Arithmetic
+		{
+			"Addition",
+			`1 + 2`,
+			3.0,
+		},
+		{
+			"Subtraction",
+			`5 - 2`,
+			3.0,
+		},
+		{
+			"Multiplication",
+			`3 * 4`,
+			12.0,
+		},
+		{
+			"Division",
+			`10 / 4`,
+			2.5,
+		},
+		{
+			"Modulo",
+			`10 % 3`,
+			1.0,
+		},
+		{
+			"String concatenation",
+			`"foo" + "bar"`,
+			"foobar",
+		},
+		{
+			"Unary minus",
+			`1 + -2`,
+			-1.0,
+		},
+		{
+			"Unary plus",
+			`+10 - +2`,
+			8.0,
+		},
+		{
+			"Multiplicative binds tighter than additive",
+			`2 + 3 * 4`,
+			14.0,
+		},
+		{
+			"Parentheses override precedence",
+			`(2 + 3) * 4`,
+			20.0,
+		},
+		{
+			"Arithmetic result in comparison",
+			`1 + 1 == 2`,
+			true,
+		},
+		// Ternary conditional
+		{
+			"Ternary true branch",
+			`true ? "yes" : "no"`,
+			"yes",
+		},
+		{
+			"Ternary false branch",
+			`false ? "yes" : "no"`,
+			"no",
+		},
+		{
+			"Ternary with comparison condition",
+			`18 >= 18 ? "adult" : "minor"`,
+			"adult",
+		},
+		{
+			"Nested ternary",
+			`false ? "a" : true ? "b" : "c"`,
+			"b",
+		},
+		{
+			"Ternary short-circuits untaken branch",
+			`true ? 1 : 1 / 0`,
+			1.0,
+		},
+		// IN / BETWEEN / LIKE
+		{
+			"IN matches",
+			`"b" IN ("a", "b", "c")`,
+			true,
+		},
+		{
+			"IN does not match",
+			`"d" IN ("a", "b", "c")`,
+			false,
+		},
+		{
+			"IN with numbers",
+			`2 IN (1, 2, 3)`,
+			true,
+		},
+		{
+			"BETWEEN matches",
+			`5 BETWEEN 1 AND 10`,
+			true,
+		},
+		{
+			"BETWEEN does not match",
+			`15 BETWEEN 1 AND 10`,
+			false,
+		},
+		{
+			"BETWEEN on strings",
+			`"m" BETWEEN "a" AND "z"`,
+			true,
+		},
+		{
+			"LIKE with % wildcard",
+			`"Jane" LIKE "J%"`,
+			true,
+		},
+		{
+			"LIKE with _ wildcard",
+			`"Jane" LIKE "J_ne"`,
+			true,
+		},
+		{
+			"LIKE does not match",
+			`"Jane" LIKE "K%"`,
+			false,
+		},
+		// NOT / !
+		{
+			"NOT true",
+			`NOT true`,
+			false,
+		},
+		{
+			"NOT false",
+			`NOT false`,
+			true,
+		},
+		{
+			"! true",
+			`!true`,
+			false,
+		},
+		{
+			"NOT subexpression",
+			`!(true AND false)`,
+			true,
+		},
+		{
+			"NOT with AND",
+			`NOT false AND true`,
+			true,
+		},
+		{
+			"Case insensitive NOT",
+			`not TRUE`,
+			false,
+		},
 	}
 
 	for _, tt := range tests {
@@ -396,6 +590,18 @@ func TestParserWithContext(t *testing.T) {
 			&testPerson{"male", 22, true},
 			true,
 		},
+		{
+			"NOT over identifier",
+			`NOT married`,
+			&testPerson{"male", 22, false},
+			true,
+		},
+		{
+			"IN over identifier",
+			`gender IN ("male", "female")`,
+			&testPerson{"male", 22, true},
+			true,
+		},
 		{
 			"Valid complex expression",
 			`( ( gender == "male" OR (gender != "female") ) AND age < 18 AND age <= 18 AND age == 18 AND age >= 18 AND age > 18 AND married == true OR (25.25 == age OR married == FALSE) )`,
@@ -419,6 +625,77 @@ func TestParserWithContext(t *testing.T) {
 	}
 }
 
+type testKeywordPrefixedFields struct{}
+
+func (o *testKeywordPrefixedFields) GetByName(name string) (interface{}, error) {
+	switch name {
+	case "index", "internal", "interval", "likely", "notify", "note", "nothing":
+		return 1.0, nil
+	}
+	return nil, fmt.Errorf("invalid identifier")
+}
+
+// TestIdentifiersWithKeywordPrefixes guards against the lexer's Keyword and
+// LogicOp alternatives matching as a prefix of a longer identifier, e.g.
+// "index" tokenizing as "IN" + "dex", or "notify" as "NOT" + "ify".
+func TestIdentifiersWithKeywordPrefixes(t *testing.T) {
+	tests := []struct {
+		name string
+		expr string
+		want interface{}
+	}{
+		{
+			"Identifier starting with IN",
+			`index == 1`,
+			true,
+		},
+		{
+			"Identifier starting with IN (internal)",
+			`internal == 1`,
+			true,
+		},
+		{
+			"Identifier starting with IN (interval)",
+			`interval == 1`,
+			true,
+		},
+		{
+			"Identifier starting with LIKE",
+			`likely == 1`,
+			true,
+		},
+		{
+			"Identifier starting with NOT",
+			`notify == 1`,
+			true,
+		},
+		{
+			"Identifier starting with NOT (note)",
+			`note == 1`,
+			true,
+		},
+		{
+			"Identifier starting with NOT (nothing)",
+			`nothing == 1`,
+			true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			value, err := Eval(tt.expr, &testKeywordPrefixedFields{})
+
+			if err != nil {
+				t.Errorf("Eval error '%v'", err)
+			}
+
+			if value != tt.want {
+				t.Errorf("Value error: want '%v', got '%v'", tt.want, value)
+			}
+		})
+	}
+}
+
 func BenchmarkParse(b *testing.B) {
 	expr := `(1 == 1 OR 1 == 0 OR 1 == 0 OR 1 == 0 OR 1 == 0) AND 1 == 0 AND 1 == 0 AND 1 == 0 AND 1 == 0`
 	e, _ := Parse(expr)
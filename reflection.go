@@ -0,0 +1,87 @@
+// Copyright 2020 Pavel Knoblokh. All rights reserved.
+// Use of this source code is governed by MIT License
+// that can be found in the LICENSE file.
+package exprcalc
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// getByName resolves a dotted path segment against current, preferring the
+// Gettable interface and falling back to reflection over a plain Go
+// map[string]interface{} or struct.
+func getByName(current interface{}, name string) (interface{}, error) {
+	if current == nil {
+		return nil, fmt.Errorf("identifier %s on nil object", name)
+	}
+
+	if g, ok := current.(Gettable); ok {
+		return g.GetByName(name)
+	}
+
+	rv := reflect.Indirect(reflect.ValueOf(current))
+
+	switch rv.Kind() {
+	case reflect.Map:
+		return reflectMapGet(rv, name)
+	case reflect.Struct:
+		field := rv.FieldByName(name)
+		if !field.IsValid() || !field.CanInterface() {
+			return nil, fmt.Errorf("no such field %s", name)
+		}
+		return field.Interface(), nil
+	}
+
+	return nil, fmt.Errorf("%s is not an object", name)
+}
+
+// getByIndex resolves a bracketed numeric index (`items[0]`) against
+// current, preferring the Indexable interface and falling back to
+// reflection over a plain Go slice or array.
+func getByIndex(current interface{}, index int) (interface{}, error) {
+	if ix, ok := current.(Indexable); ok {
+		return ix.GetByIndex(index)
+	}
+
+	rv := reflect.Indirect(reflect.ValueOf(current))
+
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Array:
+		if index < 0 || index >= rv.Len() {
+			return nil, fmt.Errorf("index %d out of range", index)
+		}
+		return rv.Index(index).Interface(), nil
+	}
+
+	return nil, fmt.Errorf("value is not indexable by position")
+}
+
+// getByKey resolves a bracketed string key (`attrs["role"]`) against
+// current, preferring the Indexable interface and falling back to
+// reflection over a plain Go map[string]interface{}.
+func getByKey(current interface{}, key string) (interface{}, error) {
+	if ix, ok := current.(Indexable); ok {
+		return ix.GetByKey(key)
+	}
+
+	rv := reflect.Indirect(reflect.ValueOf(current))
+
+	switch rv.Kind() {
+	case reflect.Map:
+		return reflectMapGet(rv, key)
+	}
+
+	return nil, fmt.Errorf("value is not indexable by key")
+}
+
+func reflectMapGet(rv reflect.Value, key string) (interface{}, error) {
+	if rv.Type().Key().Kind() != reflect.String {
+		return nil, fmt.Errorf("map keys must be strings")
+	}
+	value := rv.MapIndex(reflect.ValueOf(key))
+	if !value.IsValid() {
+		return nil, fmt.Errorf("no such key %q", key)
+	}
+	return value.Interface(), nil
+}